@@ -2,13 +2,22 @@ package slogpretty
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+
+	pkgerrors "github.com/pkg/errors"
+	"golang.org/x/term"
 )
 
 // ==================== Types ====================
@@ -18,10 +27,39 @@ type groupOrAttrs struct {
 }
 
 type SlogPretty struct {
-	opts Options
-	goas []groupOrAttrs
-	out  io.Writer
-	mu   *sync.Mutex
+	opts       Options
+	goas       []groupOrAttrs
+	out        io.Writer
+	mu         *sync.Mutex
+	levelCache map[slog.Level]string
+	// dynamicLevels caches the delta-rendered names (e.g. "INFO+2") of
+	// custom levels discovered at runtime. It's a pointer so WithAttrs/
+	// WithGroup clones of the handler share one cache. Safe for
+	// concurrent use since Handle may run from multiple goroutines.
+	dynamicLevels *sync.Map
+}
+
+// maxPooledBufSize caps the capacity of buffers retained in bufPool, so a
+// handful of unusually large records don't pin oversized buffers in memory.
+const maxPooledBufSize = 64 * 1024
+
+var bufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+func getBuf() *[]byte {
+	return bufPool.Get().(*[]byte)
+}
+
+func putBuf(buf *[]byte) {
+	if cap(*buf) > maxPooledBufSize {
+		return
+	}
+	*buf = (*buf)[:0]
+	bufPool.Put(buf)
 }
 
 // ==================== Initialization ====================
@@ -32,22 +70,94 @@ func New(out io.Writer, opts *Options) *SlogPretty {
 	if opts.TimeFormat == "" {
 		opts.TimeFormat = DefaultTimeFormat
 	}
+	if len(opts.ErrorKeys) == 0 {
+		opts.ErrorKeys = DefaultErrorKeys
+	}
+
+	resolvedOpts := *opts
+	resolvedOpts.Colorful = resolveColor(opts, out)
 
 	h := &SlogPretty{
 		out:  out,
 		mu:   &sync.Mutex{},
-		opts: *opts,
+		opts: resolvedOpts,
 	}
+	h.levelCache = h.buildLevelCache()
+	h.dynamicLevels = &sync.Map{}
 	return h
 }
 
+// buildLevelCache pre-renders the colorized level strings for the four
+// built-in levels, plus any exact names/colors registered via
+// Options.LevelNames/LevelColors, once, so Handle never re-wraps ANSI
+// codes per record.
+func (h *SlogPretty) buildLevelCache() map[slog.Level]string {
+	names := map[slog.Level]string{
+		slog.LevelDebug: "DEBUG",
+		slog.LevelInfo:  "INFO",
+		slog.LevelWarn:  "WARN",
+		slog.LevelError: "ERROR",
+	}
+	colors := map[slog.Level]int{
+		slog.LevelDebug: lightMagenta,
+		slog.LevelInfo:  lightCyan,
+		slog.LevelWarn:  lightYellow,
+		slog.LevelError: lightRed,
+	}
+	for lvl, name := range h.opts.LevelNames {
+		names[lvl] = name
+	}
+	for lvl, color := range h.opts.LevelColors {
+		colors[lvl] = color
+	}
+
+	cache := make(map[slog.Level]string, len(names))
+	for lvl, name := range names {
+		if h.opts.Colorful {
+			color, ok := colors[lvl]
+			if !ok {
+				color = white
+			}
+			cache[lvl] = colorize(color, name)
+		} else {
+			cache[lvl] = name
+		}
+	}
+	return cache
+}
+
+// resolveColor decides whether the handler should emit ANSI colors.
+// NoColor always wins; an explicit Colorful=true is always respected;
+// otherwise colors are enabled via ForceColors or terminal auto-detection.
+func resolveColor(opts *Options, out io.Writer) bool {
+	if opts.NoColor {
+		return false
+	}
+	if opts.Colorful {
+		return true
+	}
+	return opts.ForceColors || isTerminal(out)
+}
+
+// isTerminal reports whether out is a terminal, so plain files and pipes
+// don't receive ANSI escapes unless explicitly requested.
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
 // ==================== Handler Interface Methods ====================
 func (h *SlogPretty) Enabled(ctx context.Context, level slog.Level) bool {
 	return level >= h.opts.Level.Level()
 }
 
 func (h *SlogPretty) Handle(ctx context.Context, r slog.Record) error {
-	buf := make([]byte, 0, 1024)
+	bufPtr := getBuf()
+	defer putBuf(bufPtr)
+	buf := *bufPtr
 
 	buf = h.appendHeader(buf, r)
 	h.removeEmptyGroup(r)
@@ -59,6 +169,8 @@ func (h *SlogPretty) Handle(ctx context.Context, r slog.Record) error {
 	}
 
 	buf = append(buf, '\n')
+	*bufPtr = buf
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	_, err := h.out.Write(buf)
@@ -84,36 +196,70 @@ func (h *SlogPretty) WithGroup(name string) slog.Handler {
 func (h *SlogPretty) appendHeader(buf []byte, r slog.Record) []byte {
 	// Timestamp
 	if !r.Time.IsZero() {
-		timeStr := r.Time.Format(h.opts.TimeFormat)
-		if h.opts.Colorful {
-			timeStr = colorize(lightGray, timeStr)
+		if a := h.replaceAttr(nil, slog.Time(slog.TimeKey, r.Time)); !a.Equal(slog.Attr{}) {
+			var timeStr string
+			if a.Value.Kind() == slog.KindTime {
+				timeStr = a.Value.Time().Format(h.opts.TimeFormat)
+			} else {
+				timeStr = a.Value.String()
+			}
+			if h.opts.Colorful {
+				timeStr = colorize(lightGray, timeStr)
+			}
+			buf = fmt.Appendf(buf, "%s ", timeStr)
 		}
-		buf = fmt.Appendf(buf, "%s ", timeStr)
 	}
 
 	// Level
-	levelStr := h.setColorLevel(r.Level)
-	buf = fmt.Appendf(buf, "%-7s", levelStr)
+	if a := h.replaceAttr(nil, slog.Any(slog.LevelKey, r.Level)); !a.Equal(slog.Attr{}) {
+		var levelStr string
+		if lvl, ok := a.Value.Any().(slog.Level); ok {
+			levelStr = h.setColorLevel(lvl)
+		} else {
+			levelStr = a.Value.String()
+		}
+		buf = fmt.Appendf(buf, "%-7s", levelStr)
+	}
 
 	// Message
-	msg := r.Message
-	msg = colorize(white, msg)
-	buf = fmt.Appendf(buf, " %s", msg)
+	if a := h.replaceAttr(nil, slog.String(slog.MessageKey, r.Message)); !a.Equal(slog.Attr{}) {
+		msg := a.Value.String()
+		if h.opts.Colorful {
+			msg = colorize(white, msg)
+		}
+		buf = fmt.Appendf(buf, " %s", msg)
+	}
 
 	// Source location
 	if h.opts.AddSource && r.PC != 0 {
 		fs := runtime.CallersFrames([]uintptr{r.PC})
 		f, _ := fs.Next()
-		file := filepath.Base(f.File)
-		source := fmt.Sprintf("source: %s:%d", file, f.Line)
-		if h.opts.Colorful {
-			source = colorize(darkGray, source)
+		src := &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+		if a := h.replaceAttr(nil, slog.Any(slog.SourceKey, src)); !a.Equal(slog.Attr{}) {
+			var source string
+			if s, ok := a.Value.Any().(*slog.Source); ok {
+				source = fmt.Sprintf("source: %s:%d", filepath.Base(s.File), s.Line)
+			} else {
+				source = a.Value.String()
+			}
+			if h.opts.Colorful {
+				source = colorize(darkGray, source)
+			}
+			buf = fmt.Appendf(buf, " %s", source)
 		}
-		buf = fmt.Appendf(buf, " %s", source)
 	}
 	return buf
 }
 
+// replaceAttr applies Options.ReplaceAttr to a, if configured, passing
+// through unchanged otherwise.
+func (h *SlogPretty) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if h.opts.ReplaceAttr == nil {
+		return a
+	}
+	return h.opts.ReplaceAttr(groups, a)
+}
+
 func (h *SlogPretty) withGroupOrAttrs(goa groupOrAttrs) *SlogPretty {
 	h2 := *h
 	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
@@ -132,16 +278,18 @@ func (h *SlogPretty) removeEmptyGroup(r slog.Record) {
 }
 
 func (h *SlogPretty) appendMultilineGroupOrAttrs(buf []byte, level int) []byte {
+	var groups []string
 	for _, goa := range h.goas {
 		if goa.group != "" {
 			buf = fmt.Appendf(buf, "%s%s:", strings.Repeat("  ", level), colorize(magenta, goa.group))
 			level++
+			groups = append(groups, goa.group)
 		} else {
 			if len(goa.attrs) > 0 {
 				buf = append(buf, '\n')
 			}
 			for _, a := range goa.attrs {
-				buf = h.appendAttr(buf, a, true, level)
+				buf = h.appendAttr(buf, a, true, level, groups)
 			}
 		}
 	}
@@ -162,8 +310,9 @@ func (h *SlogPretty) appendMultilineAttrs(buf []byte, r slog.Record) []byte {
 
 	buf = append(buf, '\n')
 
+	groups := h.currentGroups()
 	r.Attrs(func(a slog.Attr) bool {
-		buf = h.appendAttr(buf, a, true, 1)
+		buf = h.appendAttr(buf, a, true, 1, groups)
 		return true
 	})
 
@@ -171,6 +320,7 @@ func (h *SlogPretty) appendMultilineAttrs(buf []byte, r slog.Record) []byte {
 }
 
 func (h *SlogPretty) appendInLineAttrs(buf []byte, r slog.Record) []byte {
+	var groups []string
 	for _, goa := range h.goas {
 		if goa.group != "" {
 			if h.opts.Colorful {
@@ -178,20 +328,34 @@ func (h *SlogPretty) appendInLineAttrs(buf []byte, r slog.Record) []byte {
 			} else {
 				buf = fmt.Appendf(buf, " %s:", goa.group)
 			}
+			groups = append(groups, goa.group)
 		}
 		for _, attr := range goa.attrs {
-			buf = h.appendAttr(buf, attr, false, 0)
+			buf = h.appendAttr(buf, attr, false, 0, groups)
 		}
 	}
 	r.Attrs(func(a slog.Attr) bool {
-		buf = h.appendAttr(buf, a, false, 0)
+		buf = h.appendAttr(buf, a, false, 0, groups)
 		return true
 	})
 
 	return buf
 }
 
-func (h *SlogPretty) appendAttr(buf []byte, a slog.Attr, multiline bool, level int) []byte {
+// currentGroups returns the names of the groups currently open via
+// WithGroup, outermost first, for use as the groups argument to
+// ReplaceAttr.
+func (h *SlogPretty) currentGroups() []string {
+	var groups []string
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			groups = append(groups, goa.group)
+		}
+	}
+	return groups
+}
+
+func (h *SlogPretty) appendAttr(buf []byte, a slog.Attr, multiline bool, level int, groups []string) []byte {
 	// Identation
 	indent := strings.Repeat(" ", 2*level)
 
@@ -200,6 +364,23 @@ func (h *SlogPretty) appendAttr(buf []byte, a slog.Attr, multiline bool, level i
 		return buf
 	}
 
+	// Groups are recursed into directly, without going through
+	// ReplaceAttr themselves; their key is appended to groups for the
+	// attrs nested inside.
+	if a.Value.Kind() == slog.KindGroup {
+		return h.appendGroupAttr(buf, a, multiline, level, groups)
+	}
+
+	a = h.replaceAttr(groups, a)
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if err, ok := h.attrAsError(a); ok {
+		return h.appendErrorAttr(buf, a.Key, err, multiline, level, indent)
+	}
+
 	keyColor := lightMagenta
 	valColor := lightBlue
 
@@ -257,24 +438,12 @@ func (h *SlogPretty) appendAttr(buf []byte, a slog.Attr, multiline bool, level i
 				colorize(keyColor, a.Key),
 				colorize(valColor, val))
 		}
-	case slog.KindGroup:
-		attrs := a.Value.Group()
-		if len(attrs) == 0 {
-			return buf
-		}
-
-		if multiline {
-			buf = fmt.Appendf(buf, "%s%s:\n", indent, colorize(keyColor, a.Key))
-			for _, ga := range attrs {
-				buf = h.appendAttr(buf, ga, multiline, level+1)
-			}
-		} else {
-			buf = fmt.Appendf(buf, " %s:", colorize(keyColor, a.Key))
-			for _, ga := range attrs {
-				buf = h.appendAttr(buf, ga, multiline, 2)
+	default:
+		if h.opts.PrettyJSON && isJSONPrettyValue(a.Value.Any()) {
+			if b, ok := h.appendJSONAttr(buf, a.Key, a.Value.Any(), multiline, level, indent, keyColor); ok {
+				return b
 			}
 		}
-	default:
 		if multiline {
 			buf = fmt.Appendf(buf, "%s%s: %s\n",
 				indent,
@@ -290,17 +459,272 @@ func (h *SlogPretty) appendAttr(buf []byte, a slog.Attr, multiline bool, level i
 	return buf
 }
 
+// attrAsError reports whether a should be rendered as an error, either
+// because its value is one or because its key is in Options.ErrorKeys.
+func (h *SlogPretty) attrAsError(a slog.Attr) (error, bool) {
+	if a.Value.Kind() == slog.KindAny {
+		if err, ok := a.Value.Any().(error); ok {
+			return err, true
+		}
+	}
+	for _, key := range h.opts.ErrorKeys {
+		if a.Key == key {
+			return errors.New(a.Value.String()), true
+		}
+	}
+	return nil, false
+}
+
+// stackTracer matches the interface github.com/pkg/errors errors satisfy,
+// used to recover a frame-by-frame stack trace for Options.StackTrace.
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// errorFrames returns a human-readable "file:line" for each frame of a
+// pkg/errors stack trace (most recent call first) or, failing that, the
+// message of each error wrapped by err (outermost first), for use when
+// Options.StackTrace is enabled. Returns nil if err carries no trace and
+// wraps nothing.
+func (h *SlogPretty) errorFrames(err error) []string {
+	if st, ok := err.(stackTracer); ok {
+		trace := st.StackTrace()
+		frames := make([]string, 0, len(trace))
+		for _, f := range trace {
+			frames = append(frames, fmt.Sprintf("%v", f))
+		}
+		return frames
+	}
+
+	var frames []string
+	for wrapped := errors.Unwrap(err); wrapped != nil; wrapped = errors.Unwrap(wrapped) {
+		frames = append(frames, wrapped.Error())
+	}
+	return frames
+}
+
+// appendErrorAttr renders an error-valued attr in red. When
+// Options.StackTrace is set, multiline mode lists every frame of the stack
+// trace or unwrap chain underneath it, while inline mode appends only the
+// first frame in parentheses.
+func (h *SlogPretty) appendErrorAttr(buf []byte, key string, err error, multiline bool, level int, indent string) []byte {
+	keyColor, valColor := lightRed, lightRed
+	if !h.opts.Colorful {
+		keyColor, valColor = 0, 0
+	}
+
+	msg := err.Error()
+	if multiline {
+		buf = fmt.Appendf(buf, "%s%s: %s\n", indent, colorize(keyColor, key), colorize(valColor, msg))
+		if h.opts.StackTrace {
+			frameIndent := strings.Repeat(" ", 2*(level+1))
+			for _, frame := range h.errorFrames(err) {
+				if h.opts.Colorful {
+					frame = colorize(darkGray, frame)
+				}
+				buf = fmt.Appendf(buf, "%s%s\n", frameIndent, frame)
+			}
+		}
+		return buf
+	}
+
+	rendered := msg
+	if h.opts.StackTrace {
+		if frames := h.errorFrames(err); len(frames) > 0 {
+			rendered = fmt.Sprintf("%s (%s)", msg, frames[0])
+		}
+	}
+	return fmt.Appendf(buf, " %s=%s", colorize(keyColor, key), colorize(valColor, fmt.Sprintf("%q", rendered)))
+}
+
+// isJSONPrettyValue reports whether v is a map, slice, array, or struct
+// (directly or through pointers), the shapes worth pretty-printing as
+// JSON instead of Go's default %v formatting.
+func isJSONPrettyValue(v any) bool {
+	if v == nil {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendJSONAttr renders v as indented, syntax-colored JSON in multiline
+// mode or compact JSON inline. Returns ok=false (leaving buf untouched)
+// when v can't be marshaled, so the caller can fall back to %v.
+func (h *SlogPretty) appendJSONAttr(buf []byte, key string, v any, multiline bool, level int, indent string, keyColor int) ([]byte, bool) {
+	if multiline {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return buf, false
+		}
+		buf = fmt.Appendf(buf, "%s%s:\n", indent, colorize(keyColor, key))
+		bodyIndent := strings.Repeat(" ", 2*(level+1))
+		for _, line := range strings.Split(string(data), "\n") {
+			buf = fmt.Appendf(buf, "%s%s\n", bodyIndent, h.colorizeJSONLine(line))
+		}
+		return buf, true
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return buf, false
+	}
+	rendered := string(data)
+	if h.opts.Colorful {
+		rendered = colorize(lightBlue, rendered)
+	}
+	return fmt.Appendf(buf, " %s=%s", colorize(keyColor, key), rendered), true
+}
+
+// jsonKeyValueRe splits a line of indented JSON into a quoted key, the
+// "key:" separator, and everything after it.
+var jsonKeyValueRe = regexp.MustCompile(`^("(?:[^"\\]|\\.)*")(:\s*)(.*)$`)
+
+// colorizeJSONLine colorizes a single line from json.MarshalIndent
+// output, distinguishing the key from its value.
+func (h *SlogPretty) colorizeJSONLine(line string) string {
+	if !h.opts.Colorful {
+		return line
+	}
+
+	trimmed := strings.TrimLeft(line, " ")
+	prefix := line[:len(line)-len(trimmed)]
+
+	if m := jsonKeyValueRe.FindStringSubmatch(trimmed); m != nil {
+		key, sep, rest := m[1], m[2], m[3]
+		return prefix + colorize(lightMagenta, key) + sep + h.colorizeJSONValue(rest)
+	}
+
+	return prefix + h.colorizeJSONValue(trimmed)
+}
+
+// colorizeJSONValue colors a bare JSON value (string/number/bool/null),
+// leaving brackets, braces, and anything it doesn't recognize untouched.
+func (h *SlogPretty) colorizeJSONValue(s string) string {
+	value, trailer := s, ""
+	if strings.HasSuffix(value, ",") {
+		value, trailer = value[:len(value)-1], ","
+	}
+
+	switch {
+	case value == "" || value == "{" || value == "}" || value == "[" || value == "]" || value == "{}" || value == "[]":
+		return s
+	case strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`):
+		return colorize(lightBlue, value) + trailer
+	case value == "true" || value == "false":
+		return colorize(lightYellow, value) + trailer
+	case value == "null":
+		return colorize(darkGray, value) + trailer
+	default:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return colorize(lightCyan, value) + trailer
+		}
+		return s
+	}
+}
+
+// appendGroupAttr renders a slog.KindGroup attr and recurses into its
+// members with a.Key appended to groups, mirroring how the stdlib
+// handlers scope ReplaceAttr around group boundaries.
+func (h *SlogPretty) appendGroupAttr(buf []byte, a slog.Attr, multiline bool, level int, groups []string) []byte {
+	indent := strings.Repeat(" ", 2*level)
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return buf
+	}
+
+	keyColor := lightMagenta
+	if !h.opts.Colorful {
+		keyColor = 0
+	}
+
+	nested := append(append([]string{}, groups...), a.Key)
+
+	if multiline {
+		buf = fmt.Appendf(buf, "%s%s:\n", indent, colorize(keyColor, a.Key))
+		for _, ga := range attrs {
+			buf = h.appendAttr(buf, ga, multiline, level+1, nested)
+		}
+	} else {
+		buf = fmt.Appendf(buf, " %s:", colorize(keyColor, a.Key))
+		for _, ga := range attrs {
+			buf = h.appendAttr(buf, ga, multiline, 2, nested)
+		}
+	}
+
+	return buf
+}
+
 func (h *SlogPretty) setColorLevel(level slog.Level) string {
-	switch level {
+	if s, ok := h.levelCache[level]; ok {
+		return s
+	}
+	if v, ok := h.dynamicLevels.Load(level); ok {
+		return v.(string)
+	}
+
+	s := h.renderDeltaLevel(level)
+	h.dynamicLevels.Store(level, s)
+	return s
+}
+
+// renderDeltaLevel renders a level that isn't one of the four built-ins
+// or a key of Options.LevelNames as "BASE+n"/"BASE-n", where BASE is the
+// highest of the four built-in levels at or below it (DEBUG for levels
+// below DEBUG, giving "DEBUG-n") and n is the signed distance to it.
+func (h *SlogPretty) renderDeltaLevel(level slog.Level) string {
+	base, name := slog.LevelDebug, "DEBUG"
+	switch {
+	case level >= slog.LevelError:
+		base, name = slog.LevelError, "ERROR"
+	case level >= slog.LevelWarn:
+		base, name = slog.LevelWarn, "WARN"
+	case level >= slog.LevelInfo:
+		base, name = slog.LevelInfo, "INFO"
+	}
+	if override, ok := h.opts.LevelNames[base]; ok {
+		name = override
+	}
+
+	rendered := name
+	if delta := int(level - base); delta != 0 {
+		rendered = fmt.Sprintf("%s%+d", name, delta)
+	}
+
+	if !h.opts.Colorful {
+		return rendered
+	}
+
+	color, ok := h.opts.LevelColors[base]
+	if !ok {
+		color = h.defaultLevelColor(base)
+	}
+	return colorize(color, rendered)
+}
+
+func (h *SlogPretty) defaultLevelColor(base slog.Level) int {
+	switch base {
 	case slog.LevelDebug:
-		return colorize(lightMagenta, "DEBUG")
+		return lightMagenta
 	case slog.LevelInfo:
-		return colorize(lightCyan, "INFO")
+		return lightCyan
 	case slog.LevelWarn:
-		return colorize(lightYellow, "WARN")
+		return lightYellow
 	case slog.LevelError:
-		return colorize(lightRed, "ERROR")
+		return lightRed
 	default:
-		return level.String()
+		return white
 	}
 }
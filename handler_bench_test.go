@@ -0,0 +1,55 @@
+package slogpretty
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func newBenchHandler() *SlogPretty {
+	return New(io.Discard, &Options{
+		Level:     slog.LevelDebug,
+		Colorful:  true,
+		Multiline: false,
+	})
+}
+
+func BenchmarkHandle_SingleAttr(b *testing.B) {
+	logger := slog.New(newBenchHandler())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled", "status", 200)
+	}
+}
+
+func BenchmarkHandle_ManyAttrs(b *testing.B) {
+	logger := slog.New(newBenchHandler())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled",
+			"method", "GET",
+			"path", "/users/42",
+			"status", 200,
+			"duration_ms", 12.5,
+			"user_id", 42,
+			"ip", "10.0.0.1",
+			"cached", false,
+		)
+	}
+}
+
+func BenchmarkHandle_GroupedAttrs(b *testing.B) {
+	logger := slog.New(newBenchHandler()).WithGroup("request").With(
+		"method", "GET",
+		"path", "/users/42",
+	)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled",
+			slog.Group("response",
+				slog.Int("status", 200),
+				slog.Float64("duration_ms", 12.5),
+			),
+		)
+	}
+}
@@ -4,12 +4,68 @@ import "log/slog"
 
 const DefaultTimeFormat = "2006-01-02 15:04:05.000"
 
+// DefaultErrorKeys are the attr keys treated as errors when their value
+// isn't itself an error, e.g. logged as a plain string.
+var DefaultErrorKeys = []string{"err", "error"}
+
 type Options struct {
-	Level      slog.Level
-	AddSource  bool
+	Level     slog.Level
+	AddSource bool
+	// Colorful forces ANSI colors on when true. When false (the zero
+	// value), the effective setting falls back to ForceColors or, failing
+	// that, whether out is a detected terminal. See NoColor to disable
+	// colors unconditionally.
 	Colorful   bool
 	Multiline  bool
 	TimeFormat string
+
+	// ForceColors enables ANSI colors even when the output isn't a
+	// detected terminal (e.g. a CI runner exporting FORCE_COLOR=1).
+	// Ignored when NoColor is set or Colorful is explicitly true.
+	ForceColors bool
+	// NoColor disables ANSI colors unconditionally, taking priority over
+	// Colorful, ForceColors, and terminal auto-detection.
+	NoColor bool
+
+	// ReplaceAttr, if set, is called on every non-group attribute before
+	// it is formatted, including the built-in time/level/message/source
+	// attrs produced from the record; group attrs are not passed to it,
+	// only their members. It follows the same contract as
+	// slog.HandlerOptions.ReplaceAttr: groups is the sequence of group
+	// names the attr is nested under, and a zero slog.Attr returned from
+	// the function causes the attr to be dropped entirely.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// LevelNames registers exact display names for custom levels (e.g.
+	// a LevelTrace or LevelFatal defined alongside the four built-ins),
+	// taking precedence over the default "BASE+n"/"BASE-n" delta
+	// rendering used for any level that isn't one of the four standard
+	// ones or a key of this map.
+	LevelNames map[slog.Level]string
+	// LevelColors registers the color used for a level's name, looked up
+	// by the same key as LevelNames (or by the nearest base level when a
+	// level is rendered with a delta). Levels without an entry fall back
+	// to the package defaults.
+	LevelColors map[slog.Level]int
+
+	// StackTrace enables printing a stack trace under error-valued attrs in
+	// multiline mode; inline mode appends only the first frame, in
+	// parentheses after the error message. Frames come from a
+	// github.com/pkg/errors-style `StackTrace() errors.StackTrace`
+	// method when the error implements it, otherwise from unwrapping a
+	// chain of fmt.Errorf("...: %w", ...) errors.
+	StackTrace bool
+	// ErrorKeys marks attr keys that should be rendered as errors even
+	// when their value isn't an error type, e.g. a plain string logged
+	// under "err". Defaults to DefaultErrorKeys.
+	ErrorKeys []string
+
+	// PrettyJSON marshals map, slice, array, and struct attr values (or
+	// pointers to them) that slog stores as KindAny, after any
+	// slog.LogValuer has been resolved, with encoding/json instead of the
+	// default Go %v formatting, indenting and syntax-coloring the result in
+	// multiline mode. Other KindAny values keep the %v formatting.
+	PrettyJSON bool
 }
 
 func DefaultOptions() *Options {